@@ -1,12 +1,16 @@
 package web
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
-	"io/ioutil"
+	"math"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/alpine-hodler/driver/web/transport"
@@ -16,8 +20,37 @@ import (
 var defaultRateLimit = 1 * time.Second
 var defaultRateLimitBurstCap = 5
 
+// defaultMaxRetries is the number of retry attempts Fetch will make when MaxRetries is unset on the FetchConfig.
+var defaultMaxRetries = 3
+
+// defaultRetryBackoff is the base delay used to compute exponential backoff when RetryBackoff is unset.
+var defaultRetryBackoff = 500 * time.Millisecond
+
+// defaultRetryableStatuses are the response statuses Fetch will retry when RetryableStatuses is unset.
+var defaultRetryableStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// maxRetryBackoff caps the computed backoff delay, including jitter, regardless of attempt count.
+const maxRetryBackoff = 30 * time.Second
+
 // CoinbaseProClient is a wrapper for http.Client that can be used to make HTTP Requests to the Coinbase Pro API.
-type Client struct{ http.Client }
+type Client struct {
+	http.Client
+
+	// BypassTokens is an allow-list of tokens that skip rate limiting entirely when supplied as a FetchConfig's
+	// Token. This lets trusted internal callers (e.g. a backfill orchestrator) avoid throttling their own workers
+	// while third-party requests are still limited.
+	BypassTokens []string
+
+	// middleware is the chain installed via Use, applied around http.Client.Do for every request made through
+	// Fetch/FetchStream/FetchInto.
+	middleware []Middleware
+}
 
 func NewClient(_ context.Context, roundtripper transport.T) (*Client, error) {
 	client := new(Client)
@@ -25,22 +58,83 @@ func NewClient(_ context.Context, roundtripper transport.T) (*Client, error) {
 	return client, nil
 }
 
-// newHTTPRequest will return a new request.  If the options are set, this function will encode a body if possible.
-func newHTTPRequest(method string, u *url.URL) (*http.Request, error) {
-	return http.NewRequest(method, u.String(), nil)
+// isBypassToken reports whether token is present on the Client's BypassTokens allow-list.
+func (c *Client) isBypassToken(token string) bool {
+	if token == "" {
+		return false
+	}
+	for _, t := range c.BypassTokens {
+		if t == token {
+			return true
+		}
+	}
+	return false
 }
 
-// parseErrorMessage takes a response and a status and builder an error message to send to the server.
-func parseErrorMessage(resp *http.Response) error {
-	body, err := ioutil.ReadAll(resp.Body)
+// newHTTPRequest will return a new request bound to ctx, so canceling ctx aborts the in-flight call. cfg.Query is
+// merged into the URL's existing query string, cfg.Headers are applied, and a body is encoded from cfg.JSON (if
+// set) or taken from cfg.Body.
+func newHTTPRequest(ctx context.Context, cfg *FetchConfig) (*http.Request, error) {
+	u := *cfg.URL
+	if len(cfg.Query) > 0 {
+		q := u.Query()
+		for key, values := range cfg.Query {
+			for _, v := range values {
+				q.Add(key, v)
+			}
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	body, contentType, err := cfg.requestBody()
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, cfg.Method, u.String(), body)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	return fmt.Errorf("Status Code %v (%v): %v", resp.StatusCode, resp.Status, string(body))
+
+	for key, values := range cfg.Headers {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	return req, nil
 }
 
-// validateResponse is a switch condition that parses an error response
-func validateResponse(res *http.Response) (err error) {
+// requestBody resolves the body to send for a request: cfg.JSON takes precedence and is re-encoded fresh on every
+// call, otherwise cfg.Body is buffered the first time it's read so that later calls (i.e. retry attempts) get a
+// fresh reader over the same bytes instead of the now-drained original.
+func (cfg *FetchConfig) requestBody() (io.Reader, string, error) {
+	if cfg.JSON != nil {
+		buf := new(bytes.Buffer)
+		if err := json.NewEncoder(buf).Encode(cfg.JSON); err != nil {
+			return nil, "", fmt.Errorf("error encoding JSON body: %v", err)
+		}
+		return buf, "application/json", nil
+	}
+	if cfg.Body != nil {
+		if !cfg.bodyRead {
+			b, err := io.ReadAll(cfg.Body)
+			if err != nil {
+				return nil, "", fmt.Errorf("error reading request body: %v", err)
+			}
+			cfg.bodyBytes = b
+			cfg.bodyRead = true
+		}
+		return bytes.NewReader(cfg.bodyBytes), "", nil
+	}
+	return nil, "", nil
+}
+
+// validateResponse is a switch condition that parses an error response into an *APIError.
+func validateResponse(req *http.Request, res *http.Response) (err error) {
 	if res == nil {
 		err = fmt.Errorf("no response, check request and env file")
 	} else {
@@ -52,7 +146,7 @@ func validateResponse(res *http.Response) (err error) {
 			http.StatusNotFound,
 			http.StatusTooManyRequests,
 			http.StatusForbidden:
-			err = parseErrorMessage(res)
+			err = newAPIError(req, res)
 		}
 	}
 	return
@@ -63,6 +157,56 @@ type FetchConfig struct {
 	Method      string
 	URL         *url.URL
 	RateLimiter *rate.Limiter
+
+	// Query is merged into URL's existing query string before the request is sent.
+	Query url.Values
+
+	// Headers are applied to the outgoing request in addition to any set by middleware.
+	Headers http.Header
+
+	// Body is sent as the request body verbatim. Ignored if JSON is set.
+	Body io.Reader
+
+	// JSON, if set, is encoded as the request body and takes precedence over Body. The Content-Type header is set
+	// to "application/json" unless Headers already specifies one.
+	JSON any
+
+	// bodyBytes/bodyRead cache the first read of Body so retried attempts reuse the same bytes through a fresh
+	// reader instead of the drained original. See requestBody.
+	bodyBytes []byte
+	bodyRead  bool
+
+	// RateLimiters, when set, resolves a per-host *rate.Limiter instead of the single RateLimiter above. This lets
+	// one Client shared across several APIs rate-limit each host independently.
+	RateLimiters *RateLimiterRegistry
+
+	// RateLimitObserver adapts the resolved limiter's rate from each response's headers. Defaults to
+	// defaultRateLimitObserver, which understands the common X-RateLimit-* headers.
+	RateLimitObserver RateLimitObserver
+
+	// Token identifies the caller making this request. If it matches an entry on Client.BypassTokens, Fetch skips
+	// rate limiting for this request entirely.
+	Token string
+
+	// MaxRetries is the number of times Fetch will retry a retryable response before giving up. Defaults to
+	// defaultMaxRetries when unset.
+	MaxRetries int
+
+	// RetryBackoff is the base delay used to compute exponential backoff between retries. Defaults to
+	// defaultRetryBackoff when unset.
+	RetryBackoff time.Duration
+
+	// RetryJitter is the fraction (0-1) of the computed backoff delay to add as random jitter.
+	RetryJitter float64
+
+	// RetryableStatuses are the response statuses that will trigger a retry. Defaults to defaultRetryableStatuses
+	// when unset.
+	RetryableStatuses []int
+
+	// Timeout, if set, bounds each individual request attempt: ctx is wrapped with context.WithTimeout before the
+	// rate limiter is waited on and the request is issued, so a slow attempt is aborted without waiting for the
+	// parent context to be canceled.
+	Timeout time.Duration
 }
 
 func (cfg *FetchConfig) validate() error {
@@ -79,35 +223,166 @@ func (cfg *FetchConfig) validate() error {
 	return nil
 }
 
-// Fetch will make an HTTP request using the underlying client and endpoint.
+// Fetch will make an HTTP request using the underlying client and endpoint and read the full response body into
+// memory. It is a thin wrapper around FetchStream for callers that don't need to stream large responses.
 func Fetch(ctx context.Context, cfg *FetchConfig) ([]byte, error) {
-	if err := cfg.validate(); err != nil {
+	resp, err := FetchStream(ctx, cfg)
+	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
-	// If the rate limiter is not set, set it with defaults.
-	if cfg.RateLimiter == nil {
-		cfg.RateLimiter = rate.NewLimiter(rate.Limit(defaultRateLimit), defaultRateLimitBurstCap)
-	}
+	return io.ReadAll(resp.Body)
+}
 
-	req, err := newHTTPRequest(cfg.Method, cfg.URL)
+// FetchInto makes an HTTP request using the underlying client and endpoint and JSON-decodes the response body
+// directly into dst, avoiding the intermediate []byte allocation Fetch makes.
+func FetchInto(ctx context.Context, cfg *FetchConfig, dst any) error {
+	resp, err := FetchStream(ctx, cfg)
 	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return fmt.Errorf("error decoding response body: %v", err)
+	}
+	return nil
+}
+
+// FetchStream will make an HTTP request using the underlying client and endpoint and return the validated
+// response with its Body left open for the caller to stream or decode incrementally. The caller is responsible for
+// closing the returned response's Body. Retryable responses (see FetchConfig.RetryableStatuses) are retried with
+// exponential backoff, honoring a Retry-After header when the server sends one.
+func FetchStream(ctx context.Context, cfg *FetchConfig) (*http.Response, error) {
+	if err := cfg.validate(); err != nil {
 		return nil, err
 	}
 
-	if err != nil {
-		return nil, fmt.Errorf("error waiting on rate limiter: %v", err)
+	limiter := cfg.resolveRateLimiter()
+	bypassRateLimit := cfg.Client.isBypassToken(cfg.Token)
+	observer := cfg.RateLimitObserver
+	if observer == nil {
+		observer = defaultRateLimitObserver
 	}
 
-	resp, err := cfg.Client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error making request %+v: %v", req, err)
+	maxRetries := cfg.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = defaultMaxRetries
+	}
+	retryBackoff := cfg.RetryBackoff
+	if retryBackoff == 0 {
+		retryBackoff = defaultRetryBackoff
+	}
+	retryableStatuses := cfg.RetryableStatuses
+	if retryableStatuses == nil {
+		retryableStatuses = defaultRetryableStatuses
 	}
-	defer resp.Body.Close()
 
-	if err := validateResponse(resp); err != nil {
-		return nil, err
+	for attempt := 0; ; attempt++ {
+		attemptCtx := ctx
+		cancel := context.CancelFunc(func() {})
+		if cfg.Timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		}
+
+		if !bypassRateLimit {
+			if err := limiter.Wait(attemptCtx); err != nil {
+				cancel()
+				return nil, fmt.Errorf("error waiting on rate limiter: %v", err)
+			}
+		}
+
+		// Re-issue the request on every attempt so a fresh body reader is always sent.
+		req, err := newHTTPRequest(attemptCtx, cfg)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+
+		resp, err := cfg.Client.doer()(req)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("error making request %+v: %v", req, err)
+		}
+
+		observer.Observe(limiter, resp)
+
+		if attempt < maxRetries && isRetryableStatus(resp.StatusCode, retryableStatuses) {
+			wait := retryDelay(resp, attempt, retryBackoff, cfg.RetryJitter)
+			resp.Body.Close()
+			cancel()
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+			continue
+		}
+
+		if err := validateResponse(req, resp); err != nil {
+			resp.Body.Close()
+			cancel()
+			return nil, err
+		}
+
+		// The caller streams resp.Body, so attemptCtx must stay alive until they close it.
+		resp.Body = &cancelOnCloseBody{ReadCloser: resp.Body, cancel: cancel}
+		return resp, nil
 	}
+}
 
-	return io.ReadAll(resp.Body)
-}
\ No newline at end of file
+// cancelOnCloseBody cancels its associated context once the wrapped body is closed, so a per-attempt
+// context.WithTimeout (see FetchConfig.Timeout) doesn't leak until the deadline passes.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// isRetryableStatus reports whether status is one of the configured retryable statuses.
+func isRetryableStatus(status int, statuses []int) bool {
+	for _, s := range statuses {
+		if status == s {
+			return true
+		}
+	}
+	return false
+}
+
+// retryDelay determines how long to wait before the next retry attempt, preferring a Retry-After header on resp
+// when present and otherwise falling back to exponential backoff with jitter, capped at maxRetryBackoff.
+func retryDelay(resp *http.Response, attempt int, backoff time.Duration, jitter float64) time.Duration {
+	if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+		return d
+	}
+
+	delay := backoff * time.Duration(math.Pow(2, float64(attempt)))
+	if jitter > 0 {
+		delay += time.Duration(jitter * float64(delay) * rand.Float64())
+	}
+	if delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+	return delay
+}
+
+// parseRetryAfter parses a Retry-After header value, which may be either a number of seconds or an HTTP-date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t), true
+	}
+	return 0, false
+}
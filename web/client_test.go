@@ -0,0 +1,146 @@
+package web
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestIsRetryableStatus(t *testing.T) {
+	statuses := []int{http.StatusTooManyRequests, http.StatusServiceUnavailable}
+
+	if !isRetryableStatus(http.StatusTooManyRequests, statuses) {
+		t.Fatalf("expected %d to be retryable", http.StatusTooManyRequests)
+	}
+	if isRetryableStatus(http.StatusOK, statuses) {
+		t.Fatalf("expected %d to not be retryable", http.StatusOK)
+	}
+}
+
+// TestResolveRateLimiterDefaultRate guards against defaultRateLimit (a time.Duration) being cast directly to
+// rate.Limit instead of converted with rate.Every: the former treats 1*time.Second's nanosecond count as the
+// events/sec rate, producing an effectively unlimited limiter.
+func TestResolveRateLimiterDefaultRate(t *testing.T) {
+	cfg := &FetchConfig{}
+
+	limiter := cfg.resolveRateLimiter()
+
+	want := rate.Every(defaultRateLimit)
+	if limiter.Limit() != want {
+		t.Fatalf("expected default limiter rate of %v req/sec, got %v", want, limiter.Limit())
+	}
+}
+
+func TestParseRetryAfterSeconds(t *testing.T) {
+	d, ok := parseRetryAfter("5")
+	if !ok {
+		t.Fatalf("expected Retry-After seconds value to parse")
+	}
+	if d != 5*time.Second {
+		t.Fatalf("expected 5s, got %s", d)
+	}
+}
+
+func TestParseRetryAfterHTTPDate(t *testing.T) {
+	future := time.Now().Add(10 * time.Second).UTC()
+
+	d, ok := parseRetryAfter(future.Format(http.TimeFormat))
+	if !ok {
+		t.Fatalf("expected Retry-After HTTP-date to parse")
+	}
+	if d <= 0 || d > 11*time.Second {
+		t.Fatalf("expected delay close to 10s, got %s", d)
+	}
+}
+
+func TestParseRetryAfterEmpty(t *testing.T) {
+	if _, ok := parseRetryAfter(""); ok {
+		t.Fatalf("expected empty Retry-After to not parse")
+	}
+}
+
+func TestRetryDelayHonorsRetryAfterHeader(t *testing.T) {
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"2"}}}
+
+	d := retryDelay(resp, 5, time.Second, 0)
+	if d != 2*time.Second {
+		t.Fatalf("expected Retry-After to override backoff, got %s", d)
+	}
+}
+
+func TestRetryDelayExponentialBackoff(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+
+	d0 := retryDelay(resp, 0, time.Second, 0)
+	d1 := retryDelay(resp, 1, time.Second, 0)
+	d2 := retryDelay(resp, 2, time.Second, 0)
+
+	if d0 != time.Second || d1 != 2*time.Second || d2 != 4*time.Second {
+		t.Fatalf("expected 1s/2s/4s, got %s/%s/%s", d0, d1, d2)
+	}
+}
+
+func TestRetryDelayCapsAtMaxRetryBackoff(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+
+	d := retryDelay(resp, 20, time.Second, 0)
+	if d != maxRetryBackoff {
+		t.Fatalf("expected delay to cap at %s, got %s", maxRetryBackoff, d)
+	}
+}
+
+// TestRequestBodyBuffersBodyAcrossAttempts guards against a retried request silently sending an empty body: the
+// first call to requestBody drains cfg.Body, and every later call (i.e. each retry attempt) must still see the
+// same bytes rather than an exhausted reader.
+func TestRequestBodyBuffersBodyAcrossAttempts(t *testing.T) {
+	cfg := &FetchConfig{Body: strings.NewReader("payload-data")}
+
+	for attempt := 0; attempt < 3; attempt++ {
+		body, _, err := cfg.requestBody()
+		if err != nil {
+			t.Fatalf("attempt %d: unexpected error: %v", attempt, err)
+		}
+
+		got, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatalf("attempt %d: unexpected error reading body: %v", attempt, err)
+		}
+		if string(got) != "payload-data" {
+			t.Fatalf("attempt %d: expected %q, got %q", attempt, "payload-data", got)
+		}
+	}
+}
+
+func TestRequestBodyJSONReEncodesEveryCall(t *testing.T) {
+	cfg := &FetchConfig{JSON: map[string]string{"k": "v"}}
+
+	first, contentType, err := cfg.requestBody()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if contentType != "application/json" {
+		t.Fatalf("expected application/json content type, got %q", contentType)
+	}
+	firstBytes, err := io.ReadAll(first)
+	if err != nil {
+		t.Fatalf("unexpected error reading first body: %v", err)
+	}
+
+	second, _, err := cfg.requestBody()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	secondBytes, err := io.ReadAll(second)
+	if err != nil {
+		t.Fatalf("unexpected error reading second body: %v", err)
+	}
+
+	if !bytes.Equal(firstBytes, secondBytes) {
+		t.Fatalf("expected JSON body to re-encode identically, got %q vs %q", firstBytes, secondBytes)
+	}
+}
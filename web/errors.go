@@ -0,0 +1,95 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Sentinel errors that callers can match against with errors.Is(err, web.ErrRateLimited) and friends.
+var (
+	ErrUnauthorized = errors.New("web: unauthorized")
+	ErrRateLimited  = errors.New("web: rate limited")
+	ErrNotFound     = errors.New("web: not found")
+)
+
+// APIError is returned by Fetch when the server responds with an error status. It carries the raw request and
+// response body alongside rate-limit metadata parsed from common headers, so callers can react to 401s, 429s, etc.
+// programmatically instead of pattern-matching an error string.
+type APIError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+	Request    *http.Request
+
+	RateLimit     int
+	RateRemaining int
+	RateReset     time.Time
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("Status Code %v (%v): %v", e.StatusCode, e.Status, string(e.Body))
+}
+
+// Is allows errors.Is(err, web.ErrRateLimited) / web.ErrUnauthorized / web.ErrNotFound to match based on the
+// wrapped status code.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// Unwrap is a no-op hook today but keeps APIError part of the standard unwrap chain so a future cause (e.g. a
+// transport-level error) can be threaded through without breaking existing errors.Is/As callers.
+func (e *APIError) Unwrap() error { return nil }
+
+// newAPIError builds an APIError from resp, reading its body and parsing rate-limit headers off of common
+// conventions (X-RateLimit-* and Retry-After). It does not close resp.Body; the caller remains responsible for that.
+func newAPIError(req *http.Request, resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %v", err)
+	}
+
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       body,
+		Request:    req,
+	}
+
+	if limit, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit")); err == nil {
+		apiErr.RateLimit = limit
+	}
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		apiErr.RateRemaining = remaining
+	}
+
+	if reset, ok := parseRateReset(resp.Header.Get("X-RateLimit-Reset")); ok {
+		apiErr.RateReset = reset
+	} else if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+		apiErr.RateReset = time.Now().Add(d)
+	}
+
+	return apiErr
+}
+
+// parseRateReset parses an X-RateLimit-Reset header, which is conventionally a Unix timestamp in seconds.
+func parseRateReset(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(secs, 0), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,155 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Page is one page of results produced by Paginate, pairing the raw response body with any error encountered
+// fetching or parsing that page.
+type Page struct {
+	Body []byte
+	Err  error
+}
+
+// PageFunc inspects the previous page's body and response headers and returns the URL of the next page, or a nil
+// URL to stop paginating.
+type PageFunc func(body []byte, header http.Header) (*url.URL, error)
+
+// Paginate repeatedly fetches cfg.URL and then whatever URL next returns, until next returns a nil URL, an error
+// occurs, or ctx is canceled. Pages are delivered over the returned channel, which is closed when pagination
+// stops, turning a multi-page backfill into a single reusable primitive over Fetch instead of a bespoke loop in
+// each exchange package.
+func Paginate(ctx context.Context, cfg *FetchConfig, next PageFunc) <-chan Page {
+	pages := make(chan Page)
+
+	go func() {
+		defer close(pages)
+
+		u := cfg.URL
+		for u != nil {
+			reqCfg := *cfg
+			reqCfg.URL = u
+
+			resp, err := FetchStream(ctx, &reqCfg)
+			if err != nil {
+				pages <- Page{Err: err}
+				return
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				pages <- Page{Err: err}
+				return
+			}
+
+			select {
+			case pages <- Page{Body: body}:
+			case <-ctx.Done():
+				return
+			}
+
+			u, err = next(body, resp.Header)
+			if err != nil {
+				pages <- Page{Err: err}
+				return
+			}
+		}
+	}()
+
+	return pages
+}
+
+// linkHeaderRe matches a single RFC 5988 Link header entry, e.g. `<https://api/next>; rel="next"`.
+var linkHeaderRe = regexp.MustCompile(`<([^>]+)>;\s*rel="?(\w+)"?`)
+
+// LinkHeaderPageFunc is a PageFunc that follows the rel="next" entry of an RFC 5988 Link response header, stopping
+// once that entry is absent.
+func LinkHeaderPageFunc(_ []byte, header http.Header) (*url.URL, error) {
+	for _, link := range header.Values("Link") {
+		for _, part := range strings.Split(link, ",") {
+			m := linkHeaderRe.FindStringSubmatch(strings.TrimSpace(part))
+			if m != nil && m[2] == "next" {
+				return url.Parse(m[1])
+			}
+		}
+	}
+	return nil, nil
+}
+
+// CoinbaseProPageFunc returns a PageFunc that walks a Coinbase Pro paginated endpoint using the CB-AFTER response
+// header as the "after" query parameter of the next request against baseURL, stopping once the header is absent.
+func CoinbaseProPageFunc(baseURL *url.URL) PageFunc {
+	return func(_ []byte, header http.Header) (*url.URL, error) {
+		after := header.Get("CB-AFTER")
+		if after == "" {
+			return nil, nil
+		}
+
+		next := *baseURL
+		q := next.Query()
+		q.Set("after", after)
+		next.RawQuery = q.Encode()
+		return &next, nil
+	}
+}
+
+// KrakenPageFunc returns a PageFunc that walks a Kraken endpoint whose cursor is embedded in the JSON body (e.g.
+// the OHLC endpoint's "result.last"). jsonField is a dot-separated path into the decoded body; its value is fed
+// back as the "since" query parameter of the next request against baseURL, stopping once the field is absent.
+func KrakenPageFunc(baseURL *url.URL, jsonField string) PageFunc {
+	return func(body []byte, _ http.Header) (*url.URL, error) {
+		var doc map[string]any
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return nil, fmt.Errorf("error parsing Kraken response for pagination: %v", err)
+		}
+
+		cursor, ok := lookupJSONPath(doc, jsonField)
+		if !ok {
+			return nil, nil
+		}
+
+		next := *baseURL
+		q := next.Query()
+		q.Set("since", formatCursor(cursor))
+		next.RawQuery = q.Encode()
+		return &next, nil
+	}
+}
+
+// formatCursor renders a decoded JSON cursor value as the query-string value Kraken expects. JSON numbers decode
+// to float64, so whole-number cursors (timestamps, nonces) are rendered as plain integers rather than letting
+// fmt's default %g formatting turn them into scientific notation (e.g. "1.6250976e+09").
+func formatCursor(v any) string {
+	if f, ok := v.(float64); ok && f == math.Trunc(f) {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// lookupJSONPath resolves a dot-separated path (e.g. "result.last") against a decoded JSON document.
+func lookupJSONPath(doc map[string]any, path string) (any, bool) {
+	parts := strings.Split(path, ".")
+
+	var cur any = doc
+	for _, part := range parts {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		cur, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
@@ -0,0 +1,102 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestLinkHeaderPageFuncFindsNext(t *testing.T) {
+	header := http.Header{}
+	header.Add("Link", `<https://api.example.com/items?page=2>; rel="next", <https://api.example.com/items?page=1>; rel="prev"`)
+
+	next, err := LinkHeaderPageFunc(nil, header)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next == nil || next.String() != "https://api.example.com/items?page=2" {
+		t.Fatalf("expected next page URL, got %v", next)
+	}
+}
+
+func TestLinkHeaderPageFuncStopsWithoutNext(t *testing.T) {
+	header := http.Header{}
+	header.Add("Link", `<https://api.example.com/items?page=1>; rel="prev"`)
+
+	next, err := LinkHeaderPageFunc(nil, header)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next != nil {
+		t.Fatalf("expected no next page, got %v", next)
+	}
+}
+
+func TestCoinbaseProPageFunc(t *testing.T) {
+	base, err := url.Parse("https://api.pro.coinbase.com/fills")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pageFunc := CoinbaseProPageFunc(base)
+
+	header := http.Header{}
+	header.Set("CB-AFTER", "12345")
+
+	next, err := pageFunc(nil, header)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next == nil || next.Query().Get("after") != "12345" {
+		t.Fatalf("expected after=12345 in next URL, got %v", next)
+	}
+}
+
+func TestCoinbaseProPageFuncStopsWithoutHeader(t *testing.T) {
+	base, err := url.Parse("https://api.pro.coinbase.com/fills")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pageFunc := CoinbaseProPageFunc(base)
+
+	next, err := pageFunc(nil, http.Header{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next != nil {
+		t.Fatalf("expected no next page, got %v", next)
+	}
+}
+
+func TestKrakenPageFunc(t *testing.T) {
+	base, err := url.Parse("https://api.kraken.com/0/public/OHLC")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pageFunc := KrakenPageFunc(base, "result.last")
+
+	body := []byte(`{"result":{"last":1625097600}}`)
+
+	next, err := pageFunc(body, http.Header{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next == nil || next.Query().Get("since") != "1625097600" {
+		t.Fatalf("expected since=1625097600 in next URL, got %v", next)
+	}
+}
+
+func TestKrakenPageFuncStopsWithoutCursor(t *testing.T) {
+	base, err := url.Parse("https://api.kraken.com/0/public/OHLC")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pageFunc := KrakenPageFunc(base, "result.last")
+
+	next, err := pageFunc([]byte(`{"result":{}}`), http.Header{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next != nil {
+		t.Fatalf("expected no next page, got %v", next)
+	}
+}
@@ -0,0 +1,102 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimiterRegistry holds a *rate.Limiter per URL host, so a single Client shared across several APIs (Coinbase
+// Pro, Kraken, etc.) can rate-limit each independently instead of contending on one shared limiter.
+type RateLimiterRegistry struct {
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// NewRateLimiterRegistry returns an empty registry. Limiters are created lazily, from the package defaults, the
+// first time a host is looked up.
+func NewRateLimiterRegistry() *RateLimiterRegistry {
+	return &RateLimiterRegistry{limiters: make(map[string]*rate.Limiter)}
+}
+
+// Limiter returns the limiter registered for host, creating one from the package defaults if none exists yet.
+func (r *RateLimiterRegistry) Limiter(host string) *rate.Limiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	limiter, ok := r.limiters[host]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Every(defaultRateLimit), defaultRateLimitBurstCap)
+		r.limiters[host] = limiter
+	}
+	return limiter
+}
+
+// Set installs limiter as the one used for host, overriding any limiter already registered.
+func (r *RateLimiterRegistry) Set(host string, limiter *rate.Limiter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limiters[host] = limiter
+}
+
+// RateLimitObserver inspects a response and adapts limiter's rate/burst accordingly, so callers can proactively
+// back off as a venue's rate-limit budget runs low instead of waiting to be throttled.
+type RateLimitObserver interface {
+	Observe(limiter *rate.Limiter, resp *http.Response)
+}
+
+// RateLimitObserverFunc adapts a plain function to a RateLimitObserver.
+type RateLimitObserverFunc func(limiter *rate.Limiter, resp *http.Response)
+
+// Observe calls f.
+func (f RateLimitObserverFunc) Observe(limiter *rate.Limiter, resp *http.Response) { f(limiter, resp) }
+
+// defaultRateLimitObserver adapts the limiter's rate from the common X-RateLimit-Limit/-Remaining/-Reset headers,
+// spreading the remaining budget evenly over the time left until reset. Venues with different header conventions
+// can supply their own RateLimitObserver on FetchConfig.
+var defaultRateLimitObserver RateLimitObserver = RateLimitObserverFunc(func(limiter *rate.Limiter, resp *http.Response) {
+	if resp == nil {
+		return
+	}
+
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+
+	reset, ok := parseRateReset(resp.Header.Get("X-RateLimit-Reset"))
+	if !ok {
+		return
+	}
+
+	until := time.Until(reset)
+	if until <= 0 {
+		return
+	}
+
+	if remaining <= 0 {
+		// Budget is exhausted: clamp to near-zero until reset instead of leaving whatever (possibly fast) rate was
+		// set before, which would let requests through until the server actually 429s us.
+		limiter.SetBurst(1)
+		limiter.SetLimit(rate.Limit(1 / until.Seconds()))
+		return
+	}
+
+	limiter.SetBurst(remaining)
+	limiter.SetLimit(rate.Limit(float64(remaining) / until.Seconds()))
+})
+
+// resolveRateLimiter returns the limiter Fetch should wait on for this request: the registry's per-host limiter
+// when RateLimiters is set, otherwise the single RateLimiter on cfg (created from defaults if unset).
+func (cfg *FetchConfig) resolveRateLimiter() *rate.Limiter {
+	if cfg.RateLimiters != nil {
+		return cfg.RateLimiters.Limiter(cfg.URL.Host)
+	}
+	if cfg.RateLimiter == nil {
+		cfg.RateLimiter = rate.NewLimiter(rate.Every(defaultRateLimit), defaultRateLimitBurstCap)
+	}
+	return cfg.RateLimiter
+}